@@ -0,0 +1,34 @@
+// Package ginpay 为使用gin框架的调用方提供dev.HandleNotify的适配器。
+package ginpay
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/hong008/wechat-sdk/dev"
+)
+
+// notifier 只依赖HandleNotify，避免直接绑定具体的payer构造方式。
+type notifier interface {
+	HandleNotify(r *http.Request) (dev.ResultParam, error)
+}
+
+// NotifyHandler 包装payer.HandleNotify为gin.HandlerFunc：handle返回error即视为业务处理失败，
+// 写入FAIL应答（携带error信息）；成功则写入SUCCESS应答。
+func NotifyHandler(payer notifier, handle func(result dev.ResultParam) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, err := payer.HandleNotify(c.Request)
+		if err != nil {
+			dev.WriteNotifyResponse(c.Writer, false, err.Error())
+			return
+		}
+
+		if err := handle(result); err != nil {
+			dev.WriteNotifyResponse(c.Writer, false, err.Error())
+			return
+		}
+
+		dev.WriteNotifyResponse(c.Writer, true, "")
+	}
+}
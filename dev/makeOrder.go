@@ -178,7 +178,7 @@ func (m *myPayer) UnifiedOrder(param Params) (ResultParam, error) {
 	if err != nil {
 		return nil, err
 	}
-	result, err := postUnifiedOrder(unifiedOrderUrl, "application/xml;charset=utf-8", reader)
+	result, err := m.postUnifiedOrder(unifiedOrderUrl, "application/xml;charset=utf-8", reader)
 	if err != nil {
 		return nil, err
 	}
@@ -196,20 +196,17 @@ func (m *myPayer) UnifiedOrder(param Params) (ResultParam, error) {
 	return result, err
 }
 
-func postUnifiedOrder(url string, contentType string, body io.Reader) (*unifiedResult, error) {
-	response, err := http.Post(url, contentType, body)
+// postUnifiedOrder 通过myPayer的可配置HTTP传输层发送统一下单请求。
+// unifiedorder可以安全重试：out_trade_no由商户保证唯一，重复下单请求不会产生重复交易。
+func (m *myPayer) postUnifiedOrder(url string, contentType string, body io.Reader) (*unifiedResult, error) {
+	respBody, err := m.doRequest(nil, http.MethodPost, url, contentType, body, true)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.StatusCode != http.StatusOK {
-		return nil, errors.New(fmt.Sprintf("http StatusCode: %v", response.StatusCode))
+	var result unifiedResult
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return nil, err
 	}
-
-	defer response.Body.Close()
-
-	var result *unifiedResult
-	err = xml.NewDecoder(response.Body).Decode(&result)
-
-	return result, err
+	return &result, nil
 }
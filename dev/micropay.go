@@ -0,0 +1,305 @@
+package dev
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hong008/wechat-sdk/pkg/e"
+	"github.com/hong008/wechat-sdk/pkg/util"
+)
+
+var (
+	micropayMustParam     = []string{"appid", "mch_id", "nonce_str", "body", "out_trade_no", "total_fee", "spbill_create_ip", "auth_code"}
+	micropayOptionalParam = []string{"device_info", "sign_type", "detail", "attach", "fee_type", "goods_tag", "limit_pay", "scene_info"}
+)
+
+const (
+	micropayUrl     = "https://api.mch.weixin.qq.com/pay/micropay"
+	microReverseUrl = "https://api.mch.weixin.qq.com/secapi/pay/reverse"
+)
+
+// microPollBackoff 是USERPAYING时查单轮询的退避间隔，累计25秒后转入撤销。
+var microPollBackoff = []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second, 10 * time.Second}
+
+type micropayResult struct {
+	ReturnCode    string `xml:"return_code"`
+	ReturnMsg     string `xml:"return_msg"`
+	Appid         string `xml:"appid"`
+	MchId         string `xml:"mch_id"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	ResultCode    string `xml:"result_code"`
+	ErrCode       string `xml:"err_code"`
+	ErrCodeDes    string `xml:"err_code_des"`
+	OpenId        string `xml:"openid"`
+	TradeType     string `xml:"trade_type"`
+	OutTradeNo    string `xml:"out_trade_no"`
+	TransactionId string `xml:"transaction_id"`
+	TimeEnd       string `xml:"time_end"`
+	TotalFee      string `xml:"total_fee"`
+	CashFee       string `xml:"cash_fee"`
+}
+
+func (r *micropayResult) Param(key string) (interface{}, error) {
+	var err error
+	switch key {
+	case "return_code":
+		return r.ReturnCode, err
+	case "return_msg":
+		return r.ReturnMsg, err
+	case "appid":
+		return r.Appid, err
+	case "mch_id":
+		return r.MchId, err
+	case "nonce_str":
+		return r.NonceStr, err
+	case "sign":
+		return r.Sign, err
+	case "result_code":
+		return r.ResultCode, err
+	case "err_code":
+		return r.ErrCode, err
+	case "err_code_des":
+		return r.ErrCodeDes, err
+	case "openid":
+		return r.OpenId, err
+	case "trade_type":
+		return r.TradeType, err
+	case "out_trade_no":
+		return r.OutTradeNo, err
+	case "transaction_id":
+		return r.TransactionId, err
+	case "time_end":
+		return r.TimeEnd, err
+	case "total_fee":
+		return r.TotalFee, err
+	case "cash_fee":
+		return r.CashFee, err
+	default:
+		err = errors.New(fmt.Sprintf("invalid key: %s", key))
+		return nil, err
+	}
+}
+
+func (r micropayResult) ListParam() Params {
+	p := make(Params)
+
+	t := reflect.TypeOf(r)
+	v := reflect.ValueOf(r)
+
+	for i := 0; i < t.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			tagName := strings.Split(string(t.Field(i).Tag), "\"")[1]
+			p[tagName] = v.Field(i).Interface()
+		}
+	}
+	return p
+}
+
+func (r *micropayResult) checkWxSign(signType string) (bool, error) {
+	if signType == "" {
+		signType = e.SignTypeMD5
+	}
+	if signType != e.SignTypeMD5 && signType != e.SignType256 {
+		return false, e.ErrSignType
+	}
+
+	param := r.ListParam()
+	keys := param.SortKey()
+	signStr := ""
+	sign := ""
+
+	for i, k := range keys {
+		if k == "sign" {
+			continue
+		}
+		var str string
+		if i == 0 {
+			str = fmt.Sprintf("%v=%v", k, param.Get(k))
+		} else {
+			str = fmt.Sprintf("&%v=%v", k, param.Get(k))
+		}
+		signStr += str
+	}
+	signStr += fmt.Sprintf("&key=%v", defaultPayer.apiKey)
+	switch signType {
+	case e.SignTypeMD5:
+		sign = strings.ToUpper(util.SignMd5(signStr))
+	case e.SignType256:
+		sign = strings.ToUpper(util.SignHMACSHA256(signStr, defaultPayer.apiKey))
+	}
+	if param.Get("sign") == nil {
+		return false, e.ErrNoSign
+	}
+	return sign == param.Get("sign").(string), nil
+}
+
+//付款码支付(刷卡支付)
+func (m *myPayer) MicroPay(param Params) (ResultParam, error) {
+	if param == nil {
+		return nil, e.ErrParams
+	}
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	param.Add("appid", m.appId)
+	param.Add("mch_id", m.mchId)
+
+	if _, ok := param["auth_code"]; !ok {
+		return nil, errors.New("need auth_code")
+	}
+
+	var signType = e.SignTypeMD5
+	if t, ok := param["sign_type"]; ok {
+		signType = t.(string)
+	}
+
+	for _, v := range micropayMustParam {
+		if v == "appid" || v == "mch_id" || v == "nonce_str" {
+			continue
+		}
+		if _, ok := param[v]; !ok {
+			return nil, errors.New(fmt.Sprintf("need %s", v))
+		}
+	}
+	for key := range param {
+		if !util.HaveInArray(micropayMustParam, key) && !util.HaveInArray(micropayOptionalParam, key) {
+			return nil, errors.New(fmt.Sprintf("no need %s param", key))
+		}
+	}
+
+	sign, err := param.Sign(signType)
+	if err != nil {
+		return nil, err
+	}
+	param.Add("sign", sign)
+	reader, err := param.MarshalXML()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.postMicropay(micropayUrl, "application/xml;charset=utf-8", reader)
+	if err != nil {
+		return nil, err
+	}
+	if result.ReturnCode != "SUCCESS" {
+		return nil, errors.New(result.ReturnMsg)
+	}
+
+	if result.ResultCode != "SUCCESS" && (result.ErrCode == "USERPAYING" || result.ErrCode == "SYSTEMERROR") {
+		return m.waitMicropayResult(result.OutTradeNo)
+	}
+
+	if result.ResultCode != "SUCCESS" {
+		return nil, errors.New(result.ErrCodeDes)
+	}
+	if ok, err := result.checkWxSign(signType); !ok || err != nil {
+		return nil, e.ErrCheckSign
+	}
+	return result, nil
+}
+
+// waitMicropayResult 在USERPAYING/SYSTEMERROR时按退避间隔轮询查单，直到拿到终态或超时后撤销交易。
+// 终态来自m.OrderQuery（其自身已完成验签），而不是在原始的micropay应答上补丁字段——
+// 否则result.Sign仍是针对原始err_code=USERPAYING报文算出的，后续checkWxSign必然失败。
+func (m *myPayer) waitMicropayResult(outTradeNo string) (ResultParam, error) {
+	for _, backoff := range microPollBackoff {
+		time.Sleep(backoff)
+
+		queryResult, state, err := m.queryMicropayState(outTradeNo)
+		if err != nil {
+			return nil, err
+		}
+		switch state {
+		case "SUCCESS":
+			return queryResult, nil
+		case "PAYERROR", "REVOKED", "NOTPAY":
+			return nil, fmt.Errorf("micropay failed with trade_state %s", state)
+		}
+		// 仍然是USERPAYING，继续下一轮退避
+	}
+
+	if err := m.reverseMicropay(outTradeNo); err != nil {
+		return nil, fmt.Errorf("micropay timed out waiting for USERPAYING and reverse failed: %w", err)
+	}
+	return nil, errors.New("micropay timed out waiting for user to finish entering PIN, transaction reversed")
+}
+
+// queryMicropayState 调用OrderQuery查单，返回已验签的结果及其trade_state，供MicroPay轮询使用。
+func (m *myPayer) queryMicropayState(outTradeNo string) (ResultParam, string, error) {
+	param := make(Params)
+	param.Add("out_trade_no", outTradeNo)
+
+	result, err := m.OrderQuery(param)
+	if err != nil {
+		return nil, "", err
+	}
+	state, err := result.Param("trade_state")
+	if err != nil {
+		return nil, "", err
+	}
+	return result, state.(string), nil
+}
+
+// reverseMicropay 在查单超时仍为USERPAYING时撤销交易，该接口要求商户证书(mTLS)。
+func (m *myPayer) reverseMicropay(outTradeNo string) error {
+	if m.certClient == nil {
+		return errors.New("reverse requires merchant certificate, call LoadCertFile or LoadCertPKCS12 first")
+	}
+
+	param := make(Params)
+	param.Add("appid", m.appId)
+	param.Add("mch_id", m.mchId)
+	param.Add("out_trade_no", outTradeNo)
+
+	sign, err := param.Sign(e.SignTypeMD5)
+	if err != nil {
+		return err
+	}
+	param.Add("sign", sign)
+	reader, err := param.MarshalXML()
+	if err != nil {
+		return err
+	}
+
+	body, err := m.doRequest(m.certClient, http.MethodPost, microReverseUrl, "application/xml;charset=utf-8", reader, false)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		ReturnCode string `xml:"return_code"`
+		ReturnMsg  string `xml:"return_msg"`
+		ResultCode string `xml:"result_code"`
+		ErrCodeDes string `xml:"err_code_des"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	if result.ReturnCode != "SUCCESS" {
+		return errors.New(result.ReturnMsg)
+	}
+	if result.ResultCode != "SUCCESS" {
+		return errors.New(result.ErrCodeDes)
+	}
+	return nil
+}
+
+// postMicropay 通过myPayer的可配置HTTP传输层发送付款码支付请求。micropay不是幂等的，不启用重试。
+func (m *myPayer) postMicropay(url string, contentType string, body io.Reader) (*micropayResult, error) {
+	respBody, err := m.doRequest(nil, http.MethodPost, url, contentType, body, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var result micropayResult
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
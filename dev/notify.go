@@ -0,0 +1,176 @@
+package dev
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/hong008/wechat-sdk/pkg/e"
+	"github.com/hong008/wechat-sdk/pkg/util"
+)
+
+type notifyResult struct {
+	ReturnCode    string `xml:"return_code"`
+	ReturnMsg     string `xml:"return_msg"`
+	Appid         string `xml:"appid"`
+	MchId         string `xml:"mch_id"`
+	DeviceInfo    string `xml:"device_info"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	SignType      string `xml:"sign_type"`
+	ResultCode    string `xml:"result_code"`
+	ErrCode       string `xml:"err_code"`
+	ErrCodeDes    string `xml:"err_code_des"`
+	OpenId        string `xml:"openid"`
+	TradeType     string `xml:"trade_type"`
+	BankType      string `xml:"bank_type"`
+	TotalFee      string `xml:"total_fee"`
+	CashFee       string `xml:"cash_fee"`
+	TransactionId string `xml:"transaction_id"`
+	OutTradeNo    string `xml:"out_trade_no"`
+	Attach        string `xml:"attach"`
+	TimeEnd       string `xml:"time_end"`
+}
+
+func (n *notifyResult) Param(key string) (interface{}, error) {
+	var err error
+	switch key {
+	case "return_code":
+		return n.ReturnCode, err
+	case "return_msg":
+		return n.ReturnMsg, err
+	case "appid":
+		return n.Appid, err
+	case "mch_id":
+		return n.MchId, err
+	case "device_info":
+		return n.DeviceInfo, err
+	case "nonce_str":
+		return n.NonceStr, err
+	case "sign":
+		return n.Sign, err
+	case "sign_type":
+		return n.SignType, err
+	case "result_code":
+		return n.ResultCode, err
+	case "err_code":
+		return n.ErrCode, err
+	case "err_code_des":
+		return n.ErrCodeDes, err
+	case "openid":
+		return n.OpenId, err
+	case "trade_type":
+		return n.TradeType, err
+	case "bank_type":
+		return n.BankType, err
+	case "total_fee":
+		return n.TotalFee, err
+	case "cash_fee":
+		return n.CashFee, err
+	case "transaction_id":
+		return n.TransactionId, err
+	case "out_trade_no":
+		return n.OutTradeNo, err
+	case "attach":
+		return n.Attach, err
+	case "time_end":
+		return n.TimeEnd, err
+	default:
+		err = errors.New(fmt.Sprintf("invalid key: %s", key))
+		return nil, err
+	}
+}
+
+func (n notifyResult) ListParam() Params {
+	p := make(Params)
+
+	t := reflect.TypeOf(n)
+	v := reflect.ValueOf(n)
+
+	for i := 0; i < t.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			tagName := strings.Split(string(t.Field(i).Tag), "\"")[1]
+			p[tagName] = v.Field(i).Interface()
+		}
+	}
+	return p
+}
+
+func (n *notifyResult) checkWxSign(signType string) (bool, error) {
+	if signType == "" {
+		signType = e.SignTypeMD5
+	}
+	if signType != e.SignTypeMD5 && signType != e.SignType256 {
+		return false, e.ErrSignType
+	}
+
+	param := n.ListParam()
+	keys := param.SortKey()
+	signStr := ""
+	sign := ""
+
+	for i, k := range keys {
+		if k == "sign" {
+			continue
+		}
+		var str string
+		if i == 0 {
+			str = fmt.Sprintf("%v=%v", k, param.Get(k))
+		} else {
+			str = fmt.Sprintf("&%v=%v", k, param.Get(k))
+		}
+		signStr += str
+	}
+	signStr += fmt.Sprintf("&key=%v", defaultPayer.apiKey)
+	switch signType {
+	case e.SignTypeMD5:
+		sign = strings.ToUpper(util.SignMd5(signStr))
+	case e.SignType256:
+		sign = strings.ToUpper(util.SignHMACSHA256(signStr, defaultPayer.apiKey))
+	}
+	if param.Get("sign") == nil {
+		return false, e.ErrNoSign
+	}
+	return sign == param.Get("sign").(string), nil
+}
+
+//支付结果通知
+func (m *myPayer) HandleNotify(r *http.Request) (ResultParam, error) {
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+
+	var result notifyResult
+	if err := xml.NewDecoder(r.Body).Decode(&result); err != nil {
+		return nil, errors.New(fmt.Sprintf("decode notify body: %v", err))
+	}
+
+	if result.ReturnCode != "SUCCESS" {
+		return nil, errors.New(result.ReturnMsg)
+	}
+	if result.ResultCode != "SUCCESS" {
+		return nil, errors.New(result.ErrCodeDes)
+	}
+
+	if ok, err := result.checkWxSign(result.SignType); !ok || err != nil {
+		return nil, e.ErrCheckSign
+	}
+	return &result, nil
+}
+
+//向微信支付服务器返回通知处理结果，使其不再重试
+func WriteNotifyResponse(w http.ResponseWriter, ok bool, msg string) {
+	returnCode := "FAIL"
+	if ok {
+		returnCode = "SUCCESS"
+	}
+	if msg == "" {
+		msg = "OK"
+	}
+
+	w.Header().Set("Content-Type", "application/xml;charset=utf-8")
+	fmt.Fprintf(w, "<xml><return_code><![CDATA[%s]]></return_code><return_msg><![CDATA[%s]]></return_msg></xml>", returnCode, msg)
+}
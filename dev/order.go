@@ -0,0 +1,577 @@
+package dev
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hong008/wechat-sdk/pkg/e"
+	"github.com/hong008/wechat-sdk/pkg/util"
+)
+
+const (
+	orderQueryUrl       = "https://api.mch.weixin.qq.com/pay/orderquery"
+	closeOrderUrl       = "https://api.mch.weixin.qq.com/pay/closeorder"
+	downloadBillUrl     = "https://api.mch.weixin.qq.com/pay/downloadbill"
+	downloadFundFlowUrl = "https://api.mch.weixin.qq.com/pay/downloadfundflow"
+)
+
+type orderQueryResult struct {
+	ReturnCode     string `xml:"return_code"`
+	ReturnMsg      string `xml:"return_msg"`
+	Appid          string `xml:"appid"`
+	MchId          string `xml:"mch_id"`
+	NonceStr       string `xml:"nonce_str"`
+	Sign           string `xml:"sign"`
+	ResultCode     string `xml:"result_code"`
+	ErrCode        string `xml:"err_code"`
+	ErrCodeDes     string `xml:"err_code_des"`
+	OpenId         string `xml:"openid"`
+	TradeType      string `xml:"trade_type"`
+	TradeState     string `xml:"trade_state"`
+	TradeStateDesc string `xml:"trade_state_desc"`
+	BankType       string `xml:"bank_type"`
+	TotalFee       string `xml:"total_fee"`
+	CashFee        string `xml:"cash_fee"`
+	TransactionId  string `xml:"transaction_id"`
+	OutTradeNo     string `xml:"out_trade_no"`
+	Attach         string `xml:"attach"`
+	TimeEnd        string `xml:"time_end"`
+}
+
+func (r *orderQueryResult) Param(key string) (interface{}, error) {
+	var err error
+	switch key {
+	case "return_code":
+		return r.ReturnCode, err
+	case "return_msg":
+		return r.ReturnMsg, err
+	case "appid":
+		return r.Appid, err
+	case "mch_id":
+		return r.MchId, err
+	case "nonce_str":
+		return r.NonceStr, err
+	case "sign":
+		return r.Sign, err
+	case "result_code":
+		return r.ResultCode, err
+	case "err_code":
+		return r.ErrCode, err
+	case "err_code_des":
+		return r.ErrCodeDes, err
+	case "openid":
+		return r.OpenId, err
+	case "trade_type":
+		return r.TradeType, err
+	case "trade_state":
+		return r.TradeState, err
+	case "trade_state_desc":
+		return r.TradeStateDesc, err
+	case "bank_type":
+		return r.BankType, err
+	case "total_fee":
+		return r.TotalFee, err
+	case "cash_fee":
+		return r.CashFee, err
+	case "transaction_id":
+		return r.TransactionId, err
+	case "out_trade_no":
+		return r.OutTradeNo, err
+	case "attach":
+		return r.Attach, err
+	case "time_end":
+		return r.TimeEnd, err
+	default:
+		err = errors.New(fmt.Sprintf("invalid key: %s", key))
+		return nil, err
+	}
+}
+
+func (r orderQueryResult) ListParam() Params {
+	p := make(Params)
+
+	t := reflect.TypeOf(r)
+	v := reflect.ValueOf(r)
+
+	for i := 0; i < t.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			tagName := strings.Split(string(t.Field(i).Tag), "\"")[1]
+			p[tagName] = v.Field(i).Interface()
+		}
+	}
+	return p
+}
+
+func (r *orderQueryResult) checkWxSign(signType string) (bool, error) {
+	if signType == "" {
+		signType = e.SignTypeMD5
+	}
+	if signType != e.SignTypeMD5 && signType != e.SignType256 {
+		return false, e.ErrSignType
+	}
+
+	param := r.ListParam()
+	keys := param.SortKey()
+	signStr := ""
+	sign := ""
+
+	for i, k := range keys {
+		if k == "sign" {
+			continue
+		}
+		var str string
+		if i == 0 {
+			str = fmt.Sprintf("%v=%v", k, param.Get(k))
+		} else {
+			str = fmt.Sprintf("&%v=%v", k, param.Get(k))
+		}
+		signStr += str
+	}
+	signStr += fmt.Sprintf("&key=%v", defaultPayer.apiKey)
+	switch signType {
+	case e.SignTypeMD5:
+		sign = strings.ToUpper(util.SignMd5(signStr))
+	case e.SignType256:
+		sign = strings.ToUpper(util.SignHMACSHA256(signStr, defaultPayer.apiKey))
+	}
+	if param.Get("sign") == nil {
+		return false, e.ErrNoSign
+	}
+	return sign == param.Get("sign").(string), nil
+}
+
+type closeOrderResult struct {
+	ReturnCode string `xml:"return_code"`
+	ReturnMsg  string `xml:"return_msg"`
+	Appid      string `xml:"appid"`
+	MchId      string `xml:"mch_id"`
+	NonceStr   string `xml:"nonce_str"`
+	Sign       string `xml:"sign"`
+	ResultCode string `xml:"result_code"`
+	ErrCode    string `xml:"err_code"`
+	ErrCodeDes string `xml:"err_code_des"`
+}
+
+func (r *closeOrderResult) Param(key string) (interface{}, error) {
+	var err error
+	switch key {
+	case "return_code":
+		return r.ReturnCode, err
+	case "return_msg":
+		return r.ReturnMsg, err
+	case "appid":
+		return r.Appid, err
+	case "mch_id":
+		return r.MchId, err
+	case "nonce_str":
+		return r.NonceStr, err
+	case "sign":
+		return r.Sign, err
+	case "result_code":
+		return r.ResultCode, err
+	case "err_code":
+		return r.ErrCode, err
+	case "err_code_des":
+		return r.ErrCodeDes, err
+	default:
+		err = errors.New(fmt.Sprintf("invalid key: %s", key))
+		return nil, err
+	}
+}
+
+func (r closeOrderResult) ListParam() Params {
+	p := make(Params)
+
+	t := reflect.TypeOf(r)
+	v := reflect.ValueOf(r)
+
+	for i := 0; i < t.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			tagName := strings.Split(string(t.Field(i).Tag), "\"")[1]
+			p[tagName] = v.Field(i).Interface()
+		}
+	}
+	return p
+}
+
+func (r *closeOrderResult) checkWxSign(signType string) (bool, error) {
+	if signType == "" {
+		signType = e.SignTypeMD5
+	}
+	if signType != e.SignTypeMD5 && signType != e.SignType256 {
+		return false, e.ErrSignType
+	}
+
+	param := r.ListParam()
+	keys := param.SortKey()
+	signStr := ""
+	sign := ""
+
+	for i, k := range keys {
+		if k == "sign" {
+			continue
+		}
+		var str string
+		if i == 0 {
+			str = fmt.Sprintf("%v=%v", k, param.Get(k))
+		} else {
+			str = fmt.Sprintf("&%v=%v", k, param.Get(k))
+		}
+		signStr += str
+	}
+	signStr += fmt.Sprintf("&key=%v", defaultPayer.apiKey)
+	switch signType {
+	case e.SignTypeMD5:
+		sign = strings.ToUpper(util.SignMd5(signStr))
+	case e.SignType256:
+		sign = strings.ToUpper(util.SignHMACSHA256(signStr, defaultPayer.apiKey))
+	}
+	if param.Get("sign") == nil {
+		return false, e.ErrNoSign
+	}
+	return sign == param.Get("sign").(string), nil
+}
+
+//查询订单
+func (m *myPayer) OrderQuery(param Params) (ResultParam, error) {
+	if param == nil {
+		return nil, e.ErrParams
+	}
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	param.Add("appid", m.appId)
+	param.Add("mch_id", m.mchId)
+
+	if _, hasTx := param["transaction_id"]; !hasTx {
+		if _, hasOut := param["out_trade_no"]; !hasOut {
+			return nil, errors.New("need transaction_id or out_trade_no")
+		}
+	}
+
+	var signType = e.SignTypeMD5
+	if t, ok := param["sign_type"]; ok {
+		signType = t.(string)
+	}
+
+	sign, err := param.Sign(signType)
+	if err != nil {
+		return nil, err
+	}
+	param.Add("sign", sign)
+	reader, err := param.MarshalXML()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.postOrderQuery(orderQueryUrl, "application/xml;charset=utf-8", reader)
+	if err != nil {
+		return nil, err
+	}
+	if result.ReturnCode != "SUCCESS" {
+		return nil, errors.New(result.ReturnMsg)
+	}
+	if result.ResultCode != "SUCCESS" {
+		return nil, errors.New(result.ErrCodeDes)
+	}
+	if ok, err := result.checkWxSign(signType); !ok || err != nil {
+		return nil, e.ErrCheckSign
+	}
+	return result, nil
+}
+
+//关闭订单
+func (m *myPayer) CloseOrder(param Params) (ResultParam, error) {
+	if param == nil {
+		return nil, e.ErrParams
+	}
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	param.Add("appid", m.appId)
+	param.Add("mch_id", m.mchId)
+
+	if _, ok := param["out_trade_no"]; !ok {
+		return nil, errors.New("need out_trade_no")
+	}
+
+	var signType = e.SignTypeMD5
+	if t, ok := param["sign_type"]; ok {
+		signType = t.(string)
+	}
+
+	sign, err := param.Sign(signType)
+	if err != nil {
+		return nil, err
+	}
+	param.Add("sign", sign)
+	reader, err := param.MarshalXML()
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := m.doRequest(nil, http.MethodPost, closeOrderUrl, "application/xml;charset=utf-8", reader, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var result closeOrderResult
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	if result.ReturnCode != "SUCCESS" {
+		return nil, errors.New(result.ReturnMsg)
+	}
+	if result.ResultCode != "SUCCESS" {
+		return nil, errors.New(result.ErrCodeDes)
+	}
+	if ok, err := result.checkWxSign(signType); !ok || err != nil {
+		return nil, e.ErrCheckSign
+	}
+	return &result, nil
+}
+
+// BillRow 是账单CSV中的一行流水记录，字段顺序对应微信支付"ALL"类型对账单的文档列序：
+// 交易时间,公众账号ID,商户号,子商户商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,
+// 交易状态,付款银行,货币种类,应结订单金额,代金券或立减优惠金额,微信退款单号,商户退款单号,
+// 退款金额,代金券或立减优惠退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,
+// 订单金额,申请退款金额。
+type BillRow struct {
+	TradeTime          string
+	TransactionId      string
+	OutTradeNo         string
+	OpenId             string
+	TradeType          string
+	TradeState         string
+	BankType           string
+	Currency           string
+	SettlementTotalFee string // 应结订单金额：扣除代金券/立减优惠后的金额
+	RefundId           string
+	OutRefundNo        string
+	RefundFee          string
+	RefundType         string
+	RefundState        string
+	GoodsName          string
+	TotalFee           string // 订单金额：订单的原始总金额
+}
+
+// BillSummary 是账单CSV末尾的汇总行。
+type BillSummary struct {
+	TotalCount     int
+	TotalFee       float64
+	TotalRefundFee float64
+}
+
+// BillResult 是DownloadBill的返回结果。
+type BillResult struct {
+	Rows    []BillRow
+	Summary BillSummary
+}
+
+//下载对账单
+func (m *myPayer) DownloadBill(param Params) (*BillResult, error) {
+	if param == nil {
+		return nil, e.ErrParams
+	}
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	param.Add("appid", m.appId)
+	param.Add("mch_id", m.mchId)
+
+	if _, ok := param["bill_date"]; !ok {
+		return nil, errors.New("need bill_date")
+	}
+	if _, ok := param["bill_type"]; !ok {
+		param.Add("bill_type", "ALL")
+	}
+
+	sign, err := param.Sign(e.SignTypeMD5)
+	if err != nil {
+		return nil, err
+	}
+	param.Add("sign", sign)
+	reader, err := param.MarshalXML()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := m.doRequest(nil, http.MethodPost, downloadBillUrl, "application/xml;charset=utf-8", reader, true)
+	if err != nil {
+		return nil, err
+	}
+
+	gzipped := param.Get("tar_type") == "GZIP"
+	return parseBillResponse(body, gzipped)
+}
+
+// parseBillResponse 解析downloadbill应答：失败时为<xml>错误报文，成功时为CSV(可能经过gzip压缩)正文。
+func parseBillResponse(body []byte, gzipped bool) (*BillResult, error) {
+	if gzipped && !bytes.HasPrefix(bytes.TrimSpace(body), []byte("<xml>")) {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("gunzip bill response: %w", err)
+		}
+		defer reader.Close()
+		body, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("gunzip bill response: %w", err)
+		}
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("<xml>")) {
+		var errResult struct {
+			ReturnCode string `xml:"return_code"`
+			ReturnMsg  string `xml:"return_msg"`
+		}
+		if err := xml.Unmarshal(trimmed, &errResult); err != nil {
+			return nil, err
+		}
+		return nil, errors.New(errResult.ReturnMsg)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(trimmed)), "\n")
+	if len(lines) < 3 {
+		return nil, errors.New("empty bill response")
+	}
+
+	// 账单末尾固定跟着两行：一行汇总表头("总交易单数,总交易额,..."),一行汇总数值，
+	// 因此流水记录取第2行到倒数第3行，汇总数值只看最后一行。
+	reader := csv.NewReader(strings.NewReader(strings.Join(lines[1:len(lines)-2], "\n")))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse bill csv: %w", err)
+	}
+
+	result := &BillResult{Rows: make([]BillRow, 0, len(records))}
+	for _, rec := range records {
+		row := BillRow{}
+		fields := make([]string, len(rec))
+		for i, f := range rec {
+			fields[i] = strings.TrimPrefix(strings.TrimSpace(f), "`")
+		}
+		for i, f := range fields {
+			switch i {
+			case 0:
+				row.TradeTime = f
+			case 5:
+				row.TransactionId = f
+			case 6:
+				row.OutTradeNo = f
+			case 7:
+				row.OpenId = f
+			case 8:
+				row.TradeType = f
+			case 9:
+				row.TradeState = f
+			case 10:
+				row.BankType = f
+			case 11:
+				row.Currency = f
+			case 12:
+				row.SettlementTotalFee = f
+			case 14:
+				row.RefundId = f
+			case 15:
+				row.OutRefundNo = f
+			case 16:
+				row.RefundFee = f
+			case 18:
+				row.RefundType = f
+			case 19:
+				row.RefundState = f
+			case 20:
+				row.GoodsName = f
+			case 24:
+				row.TotalFee = f
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	summaryFields := strings.Split(lines[len(lines)-1], ",")
+	for i, f := range summaryFields {
+		f = strings.TrimPrefix(strings.TrimSpace(f), "`")
+		switch i {
+		case 0:
+			if n, err := strconv.Atoi(f); err == nil {
+				result.Summary.TotalCount = n
+			}
+		case 1:
+			if n, err := strconv.ParseFloat(f, 64); err == nil {
+				result.Summary.TotalFee = n
+			}
+		case 2:
+			if n, err := strconv.ParseFloat(f, 64); err == nil {
+				result.Summary.TotalRefundFee = n
+			}
+		}
+	}
+	return result, nil
+}
+
+//下载资金账单(需要商户证书)
+func (m *myPayer) DownloadFundFlow(param Params) (*BillResult, error) {
+	if param == nil {
+		return nil, e.ErrParams
+	}
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	if m.certClient == nil {
+		return nil, errors.New("download fund flow requires merchant certificate, call LoadCertFile or LoadCertPKCS12 first")
+	}
+	param.Add("appid", m.appId)
+	param.Add("mch_id", m.mchId)
+
+	if _, ok := param["bill_date"]; !ok {
+		return nil, errors.New("need bill_date")
+	}
+	if _, ok := param["account_type"]; !ok {
+		return nil, errors.New("need account_type")
+	}
+	param.Add("sign_type", e.SignType256)
+
+	sign, err := param.Sign(e.SignType256)
+	if err != nil {
+		return nil, err
+	}
+	param.Add("sign", sign)
+	reader, err := param.MarshalXML()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := m.doRequest(m.certClient, http.MethodPost, downloadFundFlowUrl, "application/xml;charset=utf-8", reader, true)
+	if err != nil {
+		return nil, err
+	}
+
+	gzipped := param.Get("tar_type") == "GZIP"
+	return parseBillResponse(body, gzipped)
+}
+
+// postOrderQuery 通过myPayer的可配置HTTP传输层发送查单请求，查单是只读接口，可以安全重试。
+func (m *myPayer) postOrderQuery(url string, contentType string, body io.Reader) (*orderQueryResult, error) {
+	respBody, err := m.doRequest(nil, http.MethodPost, url, contentType, body, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result orderQueryResult
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
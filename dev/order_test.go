@@ -0,0 +1,97 @@
+package dev
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+// sampleBillCSV是按微信支付"ALL"类型对账单文档列序构造的示例账单，覆盖一笔退款交易。
+// 列序：交易时间,公众账号ID,商户号,子商户商户号,设备号,微信订单号,商户订单号,用户标识,
+// 交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券或立减优惠金额,微信退款单号,
+// 商户退款单号,退款金额,代金券或立减优惠退款金额,退款类型,退款状态,商品名称,商户数据包,
+// 手续费,费率,订单金额,申请退款金额。
+const sampleBillCSV = "" +
+	"交易时间,公众账号ID,商户号,子商户商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券或立减优惠金额,微信退款单号,商户退款单号,退款金额,代金券或立减优惠退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额\n" +
+	"`2024-01-02 15:04:05,`wx_appid,`1900000001,`,`,`4200000001202401021234567890,`out_trade_no_001,`openid_001,`NATIVE,`SUCCESS,`CFT,`CNY,`1.00,`0.00,`50000001202401026543210987,`out_refund_no_001,`0.50,`0.00,`ORIGINAL,`SUCCESS,`test goods,`,`0.00600,`0.6%,`1.50,`0.50\n" +
+	"总交易单数,总交易额,总退款金额\n" +
+	"1,1.50,0.50"
+
+func TestParseBillResponsePlain(t *testing.T) {
+	result, err := parseBillResponse([]byte(sampleBillCSV), false)
+	if err != nil {
+		t.Fatalf("parseBillResponse: %v", err)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(result.Rows))
+	}
+
+	row := result.Rows[0]
+	cases := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"TradeTime", row.TradeTime, "2024-01-02 15:04:05"},
+		{"TransactionId", row.TransactionId, "4200000001202401021234567890"},
+		{"OutTradeNo", row.OutTradeNo, "out_trade_no_001"},
+		{"OpenId", row.OpenId, "openid_001"},
+		{"TradeType", row.TradeType, "NATIVE"},
+		{"TradeState", row.TradeState, "SUCCESS"},
+		{"BankType", row.BankType, "CFT"},
+		{"Currency", row.Currency, "CNY"},
+		{"SettlementTotalFee", row.SettlementTotalFee, "1.00"},
+		{"RefundId", row.RefundId, "50000001202401026543210987"},
+		{"OutRefundNo", row.OutRefundNo, "out_refund_no_001"},
+		{"RefundFee", row.RefundFee, "0.50"},
+		{"RefundType", row.RefundType, "ORIGINAL"},
+		{"RefundState", row.RefundState, "SUCCESS"},
+		{"GoodsName", row.GoodsName, "test goods"},
+		{"TotalFee", row.TotalFee, "1.50"},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s = %q, want %q", c.name, c.got, c.want)
+		}
+	}
+
+	if result.Summary.TotalCount != 1 {
+		t.Errorf("Summary.TotalCount = %d, want 1", result.Summary.TotalCount)
+	}
+	if result.Summary.TotalFee != 1.5 {
+		t.Errorf("Summary.TotalFee = %v, want 1.5", result.Summary.TotalFee)
+	}
+	if result.Summary.TotalRefundFee != 0.5 {
+		t.Errorf("Summary.TotalRefundFee = %v, want 0.5", result.Summary.TotalRefundFee)
+	}
+}
+
+func TestParseBillResponseGzipped(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write([]byte(sampleBillCSV)); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+
+	result, err := parseBillResponse(buf.Bytes(), true)
+	if err != nil {
+		t.Fatalf("parseBillResponse: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].RefundFee != "0.50" {
+		t.Fatalf("unexpected gzipped parse result: %+v", result.Rows)
+	}
+}
+
+func TestParseBillResponseErrorXML(t *testing.T) {
+	errBody := []byte("<xml><return_code>FAIL</return_code><return_msg>签名错误</return_msg></xml>")
+	_, err := parseBillResponse(errBody, false)
+	if err == nil {
+		t.Fatal("expected error for <xml> error response, got nil")
+	}
+	if err.Error() != "签名错误" {
+		t.Errorf("err = %q, want %q", err.Error(), "签名错误")
+	}
+}
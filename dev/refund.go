@@ -0,0 +1,302 @@
+package dev
+
+import (
+	"crypto/tls"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+
+	"github.com/hong008/wechat-sdk/pkg/e"
+	"github.com/hong008/wechat-sdk/pkg/util"
+)
+
+var (
+	refundMustParam     = []string{"appid", "mch_id", "nonce_str", "out_refund_no", "total_fee", "refund_fee"}
+	refundOptionalParam = []string{"transaction_id", "out_trade_no", "sign_type", "refund_desc", "notify_url"}
+)
+
+const (
+	refundUrl      = "https://api.mch.weixin.qq.com/secapi/pay/refund"
+	refundQueryUrl = "https://api.mch.weixin.qq.com/pay/refundquery"
+)
+
+type refundResult struct {
+	ReturnCode    string `xml:"return_code"`
+	ReturnMsg     string `xml:"return_msg"`
+	Appid         string `xml:"appid"`
+	MchId         string `xml:"mch_id"`
+	NonceStr      string `xml:"nonce_str"`
+	Sign          string `xml:"sign"`
+	ResultCode    string `xml:"result_code"`
+	ErrCode       string `xml:"err_code"`
+	ErrCodeDes    string `xml:"err_code_des"`
+	TransactionId string `xml:"transaction_id"`
+	OutTradeNo    string `xml:"out_trade_no"`
+	OutRefundNo   string `xml:"out_refund_no"`
+	RefundId      string `xml:"refund_id"`
+	RefundFee     string `xml:"refund_fee"`
+	TotalFee      string `xml:"total_fee"`
+	CashFee       string `xml:"cash_fee"`
+}
+
+func (r *refundResult) Param(key string) (interface{}, error) {
+	var err error
+	switch key {
+	case "return_code":
+		return r.ReturnCode, err
+	case "return_msg":
+		return r.ReturnMsg, err
+	case "appid":
+		return r.Appid, err
+	case "mch_id":
+		return r.MchId, err
+	case "nonce_str":
+		return r.NonceStr, err
+	case "sign":
+		return r.Sign, err
+	case "result_code":
+		return r.ResultCode, err
+	case "err_code":
+		return r.ErrCode, err
+	case "err_code_des":
+		return r.ErrCodeDes, err
+	case "transaction_id":
+		return r.TransactionId, err
+	case "out_trade_no":
+		return r.OutTradeNo, err
+	case "out_refund_no":
+		return r.OutRefundNo, err
+	case "refund_id":
+		return r.RefundId, err
+	case "refund_fee":
+		return r.RefundFee, err
+	case "total_fee":
+		return r.TotalFee, err
+	case "cash_fee":
+		return r.CashFee, err
+	default:
+		err = errors.New(fmt.Sprintf("invalid key: %s", key))
+		return nil, err
+	}
+}
+
+func (r refundResult) ListParam() Params {
+	p := make(Params)
+
+	t := reflect.TypeOf(r)
+	v := reflect.ValueOf(r)
+
+	for i := 0; i < t.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			tagName := strings.Split(string(t.Field(i).Tag), "\"")[1]
+			p[tagName] = v.Field(i).Interface()
+		}
+	}
+	return p
+}
+
+func (r *refundResult) checkWxSign(signType string) (bool, error) {
+	if signType == "" {
+		signType = e.SignTypeMD5
+	}
+	if signType != e.SignTypeMD5 && signType != e.SignType256 {
+		return false, e.ErrSignType
+	}
+
+	param := r.ListParam()
+	keys := param.SortKey()
+	signStr := ""
+	sign := ""
+
+	for i, k := range keys {
+		if k == "sign" {
+			continue
+		}
+		var str string
+		if i == 0 {
+			str = fmt.Sprintf("%v=%v", k, param.Get(k))
+		} else {
+			str = fmt.Sprintf("&%v=%v", k, param.Get(k))
+		}
+		signStr += str
+	}
+	signStr += fmt.Sprintf("&key=%v", defaultPayer.apiKey)
+	switch signType {
+	case e.SignTypeMD5:
+		sign = strings.ToUpper(util.SignMd5(signStr))
+	case e.SignType256:
+		sign = strings.ToUpper(util.SignHMACSHA256(signStr, defaultPayer.apiKey))
+	}
+	if param.Get("sign") == nil {
+		return false, e.ErrNoSign
+	}
+	return sign == param.Get("sign").(string), nil
+}
+
+// LoadCertFile 加载PEM格式的商户证书(apiclient_cert.pem)和私钥(apiclient_key.pem)，
+// 用于退款、企业付款等要求双向证书认证的接口。
+func (m *myPayer) LoadCertFile(certPath, keyPath string) error {
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return errors.New(fmt.Sprintf("load cert file: %v", err))
+	}
+	m.setCertClient(cert)
+	return nil
+}
+
+// LoadCertPKCS12 加载微信支付后台下载的PKCS#12格式证书(apiclient_cert.p12)，
+// 密码默认为商户号mch_id。
+func (m *myPayer) LoadCertPKCS12(p12Path, password string) error {
+	p12Data, err := os.ReadFile(p12Path)
+	if err != nil {
+		return errors.New(fmt.Sprintf("read p12 file: %v", err))
+	}
+	privateKey, certificate, err := pkcs12.Decode(p12Data, password)
+	if err != nil {
+		return errors.New(fmt.Sprintf("decode p12 file: %v", err))
+	}
+	cert := tls.Certificate{
+		Certificate: [][]byte{certificate.Raw},
+		PrivateKey:  privateKey,
+		Leaf:        certificate,
+	}
+	m.setCertClient(cert)
+	return nil
+}
+
+// setCertClient 用证书构建一个带mTLS的*http.Client，供需要双向证书认证的接口复用。
+func (m *myPayer) setCertClient(cert tls.Certificate) {
+	m.certClient = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				Certificates: []tls.Certificate{cert},
+			},
+		},
+	}
+}
+
+//退款申请
+func (m *myPayer) Refund(param Params) (ResultParam, error) {
+	if param == nil {
+		return nil, e.ErrParams
+	}
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	if m.certClient == nil {
+		return nil, errors.New("refund requires merchant certificate, call LoadCertFile or LoadCertPKCS12 first")
+	}
+	param.Add("appid", m.appId)
+	param.Add("mch_id", m.mchId)
+
+	if _, hasTx := param["transaction_id"]; !hasTx {
+		if _, hasOut := param["out_trade_no"]; !hasOut {
+			return nil, errors.New("need transaction_id or out_trade_no")
+		}
+	}
+
+	var signType = e.SignTypeMD5
+	if t, ok := param["sign_type"]; ok {
+		signType = t.(string)
+	}
+
+	for _, v := range refundMustParam {
+		if _, ok := param[v]; !ok {
+			return nil, errors.New(fmt.Sprintf("need %s", v))
+		}
+	}
+	for key := range param {
+		if !util.HaveInArray(refundMustParam, key) && !util.HaveInArray(refundOptionalParam, key) {
+			return nil, errors.New(fmt.Sprintf("no need %s param", key))
+		}
+	}
+
+	sign, err := param.Sign(signType)
+	if err != nil {
+		return nil, err
+	}
+	param.Add("sign", sign)
+	reader, err := param.MarshalXML()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.postRefund(m.certClient, refundUrl, "application/xml;charset=utf-8", reader, false)
+	if err != nil {
+		return nil, err
+	}
+	if result.ReturnCode != "SUCCESS" {
+		return nil, errors.New(result.ReturnMsg)
+	}
+	if result.ResultCode != "SUCCESS" {
+		return nil, errors.New(result.ErrCodeDes)
+	}
+	if ok, err := result.checkWxSign(signType); !ok || err != nil {
+		return nil, e.ErrCheckSign
+	}
+	return result, nil
+}
+
+//退款查询
+func (m *myPayer) RefundQuery(param Params) (ResultParam, error) {
+	if param == nil {
+		return nil, e.ErrParams
+	}
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	param.Add("appid", m.appId)
+	param.Add("mch_id", m.mchId)
+
+	var signType = e.SignTypeMD5
+	if t, ok := param["sign_type"]; ok {
+		signType = t.(string)
+	}
+
+	sign, err := param.Sign(signType)
+	if err != nil {
+		return nil, err
+	}
+	param.Add("sign", sign)
+	reader, err := param.MarshalXML()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.postRefund(nil, refundQueryUrl, "application/xml;charset=utf-8", reader, true)
+	if err != nil {
+		return nil, err
+	}
+	if result.ReturnCode != "SUCCESS" {
+		return nil, errors.New(result.ReturnMsg)
+	}
+	if result.ResultCode != "SUCCESS" {
+		return nil, errors.New(result.ErrCodeDes)
+	}
+	if ok, err := result.checkWxSign(signType); !ok || err != nil {
+		return nil, e.ErrCheckSign
+	}
+	return result, nil
+}
+
+// postRefund 通过myPayer的可配置HTTP传输层发送退款/退款查询请求。
+// 退款本身不是幂等的，因此idempotentRetry须由调用方显式传入；退款查询是只读接口，可以安全重试。
+func (m *myPayer) postRefund(client *http.Client, url string, contentType string, body io.Reader, idempotentRetry bool) (*refundResult, error) {
+	respBody, err := m.doRequest(client, http.MethodPost, url, contentType, body, idempotentRetry)
+	if err != nil {
+		return nil, err
+	}
+
+	var result refundResult
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,79 @@
+package dev
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hong008/wechat-sdk/pkg/e"
+	"github.com/hong008/wechat-sdk/pkg/util"
+)
+
+// BuildJSAPIPayParams 用UnifiedOrder返回的prepay_id构造小程序/公众号wx.chooseWXPay所需的参数。
+func (m *myPayer) BuildJSAPIPayParams(prepayId, signType string) (Params, error) {
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	if prepayId == "" {
+		return nil, errors.New("need prepayId")
+	}
+	if signType == "" {
+		signType = e.SignTypeMD5
+	}
+
+	p := make(Params)
+	p.Add("appId", m.appId)
+	p.Add("timeStamp", strconv.FormatInt(time.Now().Unix(), 10))
+	p.Add("nonceStr", util.NonceStr(32))
+	p.Add("package", fmt.Sprintf("prepay_id=%s", prepayId))
+	p.Add("signType", signType)
+
+	sign, err := p.Sign(signType)
+	if err != nil {
+		return nil, err
+	}
+	p.Add("paySign", sign)
+	return p, nil
+}
+
+// BuildAppPayParams 用UnifiedOrder返回的prepay_id构造App支付SDK所需的PayReq参数。
+func (m *myPayer) BuildAppPayParams(prepayId string) (Params, error) {
+	if err := m.checkForPay(); err != nil {
+		return nil, err
+	}
+	if prepayId == "" {
+		return nil, errors.New("need prepayId")
+	}
+
+	p := make(Params)
+	p.Add("appid", m.appId)
+	p.Add("partnerid", m.mchId)
+	p.Add("prepayid", prepayId)
+	p.Add("package", "Sign=WXPay")
+	p.Add("noncestr", util.NonceStr(32))
+	p.Add("timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+
+	sign, err := p.Sign(e.SignTypeMD5)
+	if err != nil {
+		return nil, err
+	}
+	p.Add("sign", sign)
+	return p, nil
+}
+
+// BuildNativeQRURL 从UnifiedOrder(trade_type=NATIVE)的结果中取出code_url，供调用方生成二维码。
+func (m *myPayer) BuildNativeQRURL(result ResultParam) (string, error) {
+	if result == nil {
+		return "", e.ErrParams
+	}
+	codeUrl, err := result.Param("code_url")
+	if err != nil {
+		return "", err
+	}
+	url, _ := codeUrl.(string)
+	if url == "" {
+		return "", errors.New("result has no code_url, was trade_type NATIVE?")
+	}
+	return url, nil
+}
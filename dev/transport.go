@@ -0,0 +1,137 @@
+package dev
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// PayerOption 是myPayer构造时的函数式选项，用于配置HTTP传输层。
+type PayerOption func(*myPayer)
+
+// WithHTTPClient 使用调用方提供的*http.Client替换默认客户端，便于在测试中注入自定义RoundTripper
+// 或在生产环境接入带代理/连接池配置的客户端。
+func WithHTTPClient(hc *http.Client) PayerOption {
+	return func(m *myPayer) {
+		m.httpClient = hc
+	}
+}
+
+// WithTimeout 设置默认HTTP客户端的超时时间。
+func WithTimeout(d time.Duration) PayerOption {
+	return func(m *myPayer) {
+		if m.httpClient == nil {
+			m.httpClient = &http.Client{}
+		}
+		m.httpClient.Timeout = d
+	}
+}
+
+// WithRetry 设置网络错误或5xx时的重试次数与固定退避间隔。重试只会应用于标记为幂等的请求
+// （如UnifiedOrder，靠out_trade_no去重），不会应用于退款等非幂等接口。
+func WithRetry(n int, backoff time.Duration) PayerOption {
+	return func(m *myPayer) {
+		m.maxRetries = n
+		m.retryBackoff = backoff
+	}
+}
+
+// WithRequestLogger 在每次请求发出前回调，可用于记录出站请求日志。
+func WithRequestLogger(fn func(method, url string, body []byte)) PayerOption {
+	return func(m *myPayer) {
+		m.requestLogger = fn
+	}
+}
+
+// WithResponseLogger 在每次收到应答（或请求失败）后回调，可用于记录入站应答日志。
+func WithResponseLogger(fn func(status int, body []byte, err error)) PayerOption {
+	return func(m *myPayer) {
+		m.responseLogger = fn
+	}
+}
+
+// httpClientOrDefault 返回配置的HTTP客户端，未配置时退回http.DefaultClient。
+func (m *myPayer) httpClientOrDefault() *http.Client {
+	if m.httpClient != nil {
+		return m.httpClient
+	}
+	return http.DefaultClient
+}
+
+// doRequest 是所有出站请求的公共入口：缓冲请求体以便重试时重放、记录请求/应答日志、
+// 并在网络错误或5xx时按配置的次数和退避间隔重试。client为nil时使用默认HTTP客户端，
+// 需要双向证书认证的接口（退款、撤销、资金账单）应传入m.certClient。
+// idempotentRetry必须由调用方显式确认该请求是幂等或可安全重试的（如unifiedorder靠
+// out_trade_no去重），非幂等接口（如退款）不应开启。
+func (m *myPayer) doRequest(client *http.Client, method, url, contentType string, bodyReader io.Reader, idempotentRetry bool) ([]byte, error) {
+	var bodyBytes []byte
+	if bodyReader != nil {
+		b, err := io.ReadAll(bodyReader)
+		if err != nil {
+			return nil, err
+		}
+		bodyBytes = b
+	}
+	if m.requestLogger != nil {
+		m.requestLogger(method, url, bodyBytes)
+	}
+
+	if client == nil {
+		client = m.httpClientOrDefault()
+	}
+
+	attempts := 1
+	if idempotentRetry && m.maxRetries > 0 {
+		attempts = m.maxRetries + 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(m.retryBackoff)
+		}
+
+		req, err := http.NewRequest(method, url, bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			if m.responseLogger != nil {
+				m.responseLogger(0, nil, err)
+			}
+			continue
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			if m.responseLogger != nil {
+				m.responseLogger(resp.StatusCode, nil, err)
+			}
+			continue
+		}
+		if m.responseLogger != nil {
+			m.responseLogger(resp.StatusCode, respBody, nil)
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			lastErr = errors.New(fmt.Sprintf("http StatusCode: %v", resp.StatusCode))
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New(fmt.Sprintf("http StatusCode: %v", resp.StatusCode))
+		}
+		return respBody, nil
+	}
+	return nil, lastErr
+}
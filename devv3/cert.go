@@ -0,0 +1,131 @@
+package devv3
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// certificatesResponse 对应 GET /v3/certificates 的应答结构。
+type certificatesResponse struct {
+	Data []struct {
+		SerialNo           string `json:"serial_no"`
+		EffectiveTime      string `json:"effective_time"`
+		ExpireTime         string `json:"expire_time"`
+		EncryptCertificate struct {
+			Algorithm      string `json:"algorithm"`
+			Nonce          string `json:"nonce"`
+			AssociatedData string `json:"associated_data"`
+			Ciphertext     string `json:"ciphertext"`
+		} `json:"encrypt_certificate"`
+	} `json:"data"`
+}
+
+// FetchCertificates 拉取微信支付平台证书列表，解密并刷新本地缓存。
+//
+// 首次调用时本地尚无平台证书可用于验签，按微信支付官方SDK的做法信任首次应答；
+// 之后的刷新会使用上一次缓存的证书校验新应答的签名。
+func (c *Client) FetchCertificates() error {
+	req, err := http.NewRequest(http.MethodGet, certificatesUrl, nil)
+	if err != nil {
+		return err
+	}
+	authHeader, err := c.authorizationHeader(http.MethodGet, "/v3/certificates", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authHeader)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("devv3: GET /v3/certificates http status %d: %s", resp.StatusCode, body)
+	}
+
+	c.mu.RLock()
+	hasCerts := len(c.certs) > 0
+	c.mu.RUnlock()
+	if hasCerts {
+		serialNo := resp.Header.Get("Wechatpay-Serial")
+		timestamp := resp.Header.Get("Wechatpay-Timestamp")
+		nonce := resp.Header.Get("Wechatpay-Nonce")
+		signature := resp.Header.Get("Wechatpay-Signature")
+		if err := c.verifyResponse(serialNo, timestamp, nonce, body, signature); err != nil {
+			return err
+		}
+	}
+
+	var parsed certificatesResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return fmt.Errorf("devv3: decode certificates response: %w", err)
+	}
+
+	fresh := make(map[string]*platformCert, len(parsed.Data))
+	for _, d := range parsed.Data {
+		plaintext, err := c.decryptCertificate(d.EncryptCertificate.Nonce, d.EncryptCertificate.AssociatedData, d.EncryptCertificate.Ciphertext)
+		if err != nil {
+			return fmt.Errorf("devv3: decrypt certificate %s: %w", d.SerialNo, err)
+		}
+		block, _ := pem.Decode(plaintext)
+		if block == nil {
+			return fmt.Errorf("devv3: certificate %s is not valid PEM", d.SerialNo)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("devv3: parse certificate %s: %w", d.SerialNo, err)
+		}
+		expiresAt, err := time.Parse(time.RFC3339, d.ExpireTime)
+		if err != nil {
+			expiresAt = cert.NotAfter
+		}
+		fresh[d.SerialNo] = &platformCert{serialNo: d.SerialNo, cert: cert, expiresAt: expiresAt}
+	}
+
+	c.mu.Lock()
+	c.certs = fresh
+	c.mu.Unlock()
+	return nil
+}
+
+// decryptCertificate 用APIv3密钥对 encrypt_certificate 中的密文做AES-256-GCM解密。
+// 只有ciphertext是base64编码；nonce和associated_data是原始ASCII字符串，直接按字节使用。
+func (c *Client) decryptCertificate(nonce, associatedData, ciphertextB64 string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(ciphertextB64)
+	if err != nil {
+		return nil, fmt.Errorf("decode ciphertext: %w", err)
+	}
+
+	block, err := aes.NewCipher(c.apiv3Key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("unexpected nonce size %d", len(nonce))
+	}
+
+	plaintext, err := gcm.Open(nil, []byte(nonce), ciphertext, []byte(associatedData))
+	if err != nil {
+		return nil, fmt.Errorf("gcm open: %w", err)
+	}
+	return plaintext, nil
+}
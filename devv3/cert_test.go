@@ -0,0 +1,61 @@
+package devv3
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"testing"
+)
+
+// TestDecryptCertificate 验证nonce/associated_data按原始ASCII字节参与GCM运算，
+// 而不是先做一次base64解码——这类bug只有在加解密双方使用不同编码时才会在真实环境里现形。
+func TestDecryptCertificate(t *testing.T) {
+	apiv3Key := []byte("12345678901234567890123456789012")
+	c := &Client{apiv3Key: apiv3Key}
+
+	block, err := aes.NewCipher(apiv3Key)
+	if err != nil {
+		t.Fatalf("new cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("new gcm: %v", err)
+	}
+
+	nonce := "abcdefghijkl" // 12字节，GCM标准nonce长度
+	associatedData := "certificate"
+	plaintext := []byte("-----BEGIN CERTIFICATE-----\nfake\n-----END CERTIFICATE-----")
+
+	ciphertext := gcm.Seal(nil, []byte(nonce), plaintext, []byte(associatedData))
+	ciphertextB64 := base64.StdEncoding.EncodeToString(ciphertext)
+
+	got, err := c.decryptCertificate(nonce, associatedData, ciphertextB64)
+	if err != nil {
+		t.Fatalf("decryptCertificate: %v", err)
+	}
+	if string(got) != string(plaintext) {
+		t.Errorf("decryptCertificate = %q, want %q", got, plaintext)
+	}
+}
+
+func TestDecryptCertificateWrongAssociatedData(t *testing.T) {
+	apiv3Key := []byte("12345678901234567890123456789012")
+	c := &Client{apiv3Key: apiv3Key}
+
+	block, err := aes.NewCipher(apiv3Key)
+	if err != nil {
+		t.Fatalf("new cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("new gcm: %v", err)
+	}
+
+	nonce := "abcdefghijkl"
+	ciphertext := gcm.Seal(nil, []byte(nonce), []byte("plaintext"), []byte("certificate"))
+	ciphertextB64 := base64.StdEncoding.EncodeToString(ciphertext)
+
+	if _, err := c.decryptCertificate(nonce, "wrong-aad", ciphertextB64); err == nil {
+		t.Fatal("expected error for mismatched associated_data, got nil")
+	}
+}
@@ -0,0 +1,126 @@
+// Package devv3 实现微信支付APIv3（JSON/REST）协议，与 dev 包中的 v2 XML/MD5 协议并行存在。
+package devv3
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	baseUrl            = "https://api.mch.weixin.qq.com"
+	certificatesUrl    = baseUrl + "/v3/certificates"
+	defaultHTTPTimeout = 10 * time.Second
+)
+
+var (
+	ErrNoPrivateKey   = errors.New("devv3: missing merchant private key")
+	ErrNoPlatformCert = errors.New("devv3: no platform certificate available, call FetchCertificates first")
+	ErrBadSignature   = errors.New("devv3: response signature verification failed")
+)
+
+// platformCert 缓存一张微信支付平台证书，用于校验应答签名。
+type platformCert struct {
+	serialNo  string
+	cert      *x509.Certificate
+	expiresAt time.Time
+}
+
+// Client 是APIv3协议的客户端，持有商户身份、密钥以及平台证书缓存。
+type Client struct {
+	appId       string
+	mchId       string
+	mchSerialNo string
+	privateKey  *rsa.PrivateKey
+	apiv3Key    []byte
+
+	httpClient *http.Client
+
+	mu    sync.RWMutex
+	certs map[string]*platformCert // serial_no -> cert
+}
+
+// NewClient 使用商户号、APPID、商户证书序列号、商户私钥(PEM)和APIv3密钥构造一个Client。
+func NewClient(appId, mchId, mchSerialNo string, privateKeyPEM []byte, apiv3Key string) (*Client, error) {
+	if appId == "" || mchId == "" || mchSerialNo == "" {
+		return nil, errors.New("devv3: appId, mchId and mchSerialNo are required")
+	}
+	if len(apiv3Key) != 32 {
+		return nil, errors.New("devv3: apiv3Key must be 32 bytes")
+	}
+
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, ErrNoPrivateKey
+	}
+	key, err := parsePrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("devv3: parse private key: %w", err)
+	}
+
+	return &Client{
+		appId:       appId,
+		mchId:       mchId,
+		mchSerialNo: mchSerialNo,
+		privateKey:  key,
+		apiv3Key:    []byte(apiv3Key),
+		httpClient:  &http.Client{Timeout: defaultHTTPTimeout},
+		certs:       make(map[string]*platformCert),
+	}, nil
+}
+
+func parsePrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("devv3: private key is not RSA")
+	}
+	return rsaKey, nil
+}
+
+// WithHTTPClient 替换底层http.Client，便于在测试中注入自定义RoundTripper。
+func (c *Client) WithHTTPClient(hc *http.Client) *Client {
+	c.httpClient = hc
+	return c
+}
+
+// platformCertBySerial 按序列号取出已缓存的平台证书。
+func (c *Client) platformCertBySerial(serialNo string) (*x509.Certificate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	pc, ok := c.certs[serialNo]
+	if !ok {
+		return nil, false
+	}
+	return pc.cert, true
+}
+
+// StartCertRefresh 启动一个后台goroutine，每interval刷新一次平台证书，直到stop被关闭。
+func (c *Client) StartCertRefresh(stop <-chan struct{}, interval time.Duration) {
+	if interval <= 0 {
+		interval = 12 * time.Hour
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				_ = c.FetchCertificates()
+			}
+		}
+	}()
+}
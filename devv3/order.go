@@ -0,0 +1,181 @@
+package devv3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	jsapiOrderUrl  = baseUrl + "/v3/pay/transactions/jsapi"
+	appOrderUrl    = baseUrl + "/v3/pay/transactions/app"
+	nativeOrderUrl = baseUrl + "/v3/pay/transactions/native"
+	h5OrderUrl     = baseUrl + "/v3/pay/transactions/h5"
+)
+
+// Amount 是下单请求中的金额字段，单位为分。
+type Amount struct {
+	Total    int    `json:"total"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// Payer 是JSAPI下单必须携带的支付者信息。
+type Payer struct {
+	OpenId string `json:"openid"`
+}
+
+// SceneInfoH5 是H5下单的场景信息。
+type SceneInfoH5 struct {
+	PayerClientIp string `json:"payer_client_ip"`
+	H5Info        struct {
+		Type string `json:"type"`
+	} `json:"h5_info"`
+}
+
+// OrderRequest 是下单请求的公共字段，四种交易类型在此基础上各自扩展。
+type OrderRequest struct {
+	Description string `json:"description"`
+	OutTradeNo  string `json:"out_trade_no"`
+	NotifyUrl   string `json:"notify_url"`
+	Attach      string `json:"attach,omitempty"`
+	TimeExpire  string `json:"time_expire,omitempty"`
+	Amount      Amount `json:"amount"`
+}
+
+// JSAPIOrderRequest 是小程序/公众号支付下单请求。
+type JSAPIOrderRequest struct {
+	OrderRequest
+	Payer Payer `json:"payer"`
+}
+
+// AppOrderRequest 是App支付下单请求。
+type AppOrderRequest struct {
+	OrderRequest
+}
+
+// NativeOrderRequest 是Native(扫码)支付下单请求。
+type NativeOrderRequest struct {
+	OrderRequest
+}
+
+// H5OrderRequest 是H5支付下单请求。
+type H5OrderRequest struct {
+	OrderRequest
+	SceneInfo SceneInfoH5 `json:"scene_info"`
+}
+
+// PrepayResult 是JSAPI/App/H5下单成功的应答，包含prepay_id。
+type PrepayResult struct {
+	PrepayId string `json:"prepay_id"`
+}
+
+// NativeOrderResult 是Native下单成功的应答，包含用于生成二维码的跳转链接。
+type NativeOrderResult struct {
+	CodeUrl string `json:"code_url"`
+}
+
+// errorResponse 是APIv3统一的错误应答结构。
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// CreateJSAPIOrder 调用 /v3/pay/transactions/jsapi 创建JSAPI支付订单。
+func (c *Client) CreateJSAPIOrder(req *JSAPIOrderRequest) (*PrepayResult, error) {
+	var result PrepayResult
+	if err := c.postV3("/v3/pay/transactions/jsapi", jsapiOrderUrl, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateAppOrder 调用 /v3/pay/transactions/app 创建App支付订单。
+func (c *Client) CreateAppOrder(req *AppOrderRequest) (*PrepayResult, error) {
+	var result PrepayResult
+	if err := c.postV3("/v3/pay/transactions/app", appOrderUrl, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateNativeOrder 调用 /v3/pay/transactions/native 创建Native(扫码)支付订单。
+func (c *Client) CreateNativeOrder(req *NativeOrderRequest) (*NativeOrderResult, error) {
+	var result NativeOrderResult
+	if err := c.postV3("/v3/pay/transactions/native", nativeOrderUrl, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CreateH5Order 调用 /v3/pay/transactions/h5 创建H5支付订单。
+func (c *Client) CreateH5Order(req *H5OrderRequest) (*PrepayResult, error) {
+	var result PrepayResult
+	if err := c.postV3("/v3/pay/transactions/h5", h5OrderUrl, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// postV3 是APIv3 JSON请求的公共发送逻辑：签名、发送、验签、反序列化。
+func (c *Client) postV3(uri, url string, reqBody interface{}, out interface{}) error {
+	body, err := marshalOrderBody(c.appId, c.mchId, reqBody)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	authHeader, err := c.authorizationHeader(http.MethodPost, uri, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", authHeader)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr errorResponse
+		_ = json.Unmarshal(respBody, &apiErr)
+		return fmt.Errorf("devv3: %s http status %d: %s (%s)", uri, resp.StatusCode, apiErr.Message, apiErr.Code)
+	}
+
+	serialNo := resp.Header.Get("Wechatpay-Serial")
+	timestamp := resp.Header.Get("Wechatpay-Timestamp")
+	nonce := resp.Header.Get("Wechatpay-Nonce")
+	signature := resp.Header.Get("Wechatpay-Signature")
+	if err := c.verifyResponse(serialNo, timestamp, nonce, respBody, signature); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(respBody, out)
+}
+
+// marshalOrderBody 将appid/mchid与下单请求体合并为一个JSON对象。
+func marshalOrderBody(appId, mchId string, reqBody interface{}) ([]byte, error) {
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	var merged map[string]interface{}
+	if err := json.Unmarshal(reqJSON, &merged); err != nil {
+		return nil, err
+	}
+	merged["appid"] = appId
+	merged["mchid"] = mchId
+	return json.Marshal(merged)
+}
@@ -0,0 +1,78 @@
+package devv3
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hong008/wechat-sdk/pkg/util"
+)
+
+// buildSignMessage 构造APIv3要求的签名串：METHOD\nURI\nTIMESTAMP\nNONCE\nBODY\n
+func buildSignMessage(method, uri, timestamp, nonce, body string) string {
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n", method, uri, timestamp, nonce, body)
+}
+
+// sign 用商户私钥对message做SHA256-with-RSA签名，返回base64编码结果。
+func (c *Client) sign(message string) (string, error) {
+	if c.privateKey == nil {
+		return "", ErrNoPrivateKey
+	}
+	hashed := sha256.Sum256([]byte(message))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", fmt.Errorf("devv3: sign: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// authorizationHeader 生成 Authorization: WECHATPAY2-SHA256-RSA2048 ... 头部。
+func (c *Client) authorizationHeader(method, uri string, body []byte) (string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := util.NonceStr(32)
+
+	message := buildSignMessage(method, uri, timestamp, nonce, string(body))
+	signature, err := c.sign(message)
+	if err != nil {
+		return "", err
+	}
+
+	header := fmt.Sprintf(
+		`WECHATPAY2-SHA256-RSA2048 mchid="%s",nonce_str="%s",timestamp="%s",serial_no="%s",signature="%s"`,
+		c.mchId, nonce, timestamp, c.mchSerialNo, signature,
+	)
+	return header, nil
+}
+
+// verifyResponse 使用Wechatpay-Serial指定的平台证书校验应答签名。
+func (c *Client) verifyResponse(serialNo, timestamp, nonce string, body []byte, signature string) error {
+	cert, ok := c.platformCertBySerial(serialNo)
+	if !ok {
+		return ErrNoPlatformCert
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("devv3: platform certificate public key is not RSA")
+	}
+
+	message := buildSignMessage2(timestamp, nonce, string(body))
+	sigBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("devv3: decode signature: %w", err)
+	}
+	hashed := sha256.Sum256([]byte(message))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sigBytes); err != nil {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// buildSignMessage2 构造应答签名串：TIMESTAMP\nNONCE\nBODY\n （应答验签不含METHOD/URI）
+func buildSignMessage2(timestamp, nonce, body string) string {
+	return fmt.Sprintf("%s\n%s\n%s\n", timestamp, nonce, body)
+}
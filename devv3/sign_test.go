@@ -0,0 +1,108 @@
+package devv3
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestBuildSignMessage(t *testing.T) {
+	got := buildSignMessage("POST", "/v3/pay/transactions/native", "1700000000", "abc123", `{"foo":"bar"}`)
+	want := "POST\n/v3/pay/transactions/native\n1700000000\nabc123\n{\"foo\":\"bar\"}\n"
+	if got != want {
+		t.Errorf("buildSignMessage = %q, want %q", got, want)
+	}
+}
+
+func TestBuildSignMessage2(t *testing.T) {
+	got := buildSignMessage2("1700000000", "abc123", `{"foo":"bar"}`)
+	want := "1700000000\nabc123\n{\"foo\":\"bar\"}\n"
+	if got != want {
+		t.Errorf("buildSignMessage2 = %q, want %q", got, want)
+	}
+}
+
+// newTestClientWithCert 生成一对RSA密钥和自签名证书，构造一个可用于签名/验签往返测试的Client。
+func newTestClientWithCert(t *testing.T) (*Client, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+
+	c := &Client{
+		appId:       "wx_appid",
+		mchId:       "1900000001",
+		mchSerialNo: "serial-no",
+		privateKey:  key,
+		apiv3Key:    []byte("12345678901234567890123456789012"),
+		certs: map[string]*platformCert{
+			"serial-no": {serialNo: "serial-no", cert: cert, expiresAt: time.Now().Add(time.Hour)},
+		},
+	}
+	return c, cert
+}
+
+func TestSignAndVerifyResponseRoundTrip(t *testing.T) {
+	c, _ := newTestClientWithCert(t)
+
+	timestamp := "1700000000"
+	nonce := "testnonce"
+	body := `{"code":"SUCCESS"}`
+
+	message := buildSignMessage2(timestamp, nonce, body)
+	signature, err := c.sign(message)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	if err := c.verifyResponse("serial-no", timestamp, nonce, []byte(body), signature); err != nil {
+		t.Fatalf("verifyResponse: %v", err)
+	}
+}
+
+func TestVerifyResponseRejectsTamperedBody(t *testing.T) {
+	c, _ := newTestClientWithCert(t)
+
+	timestamp := "1700000000"
+	nonce := "testnonce"
+	body := `{"code":"SUCCESS"}`
+
+	message := buildSignMessage2(timestamp, nonce, body)
+	signature, err := c.sign(message)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	err = c.verifyResponse("serial-no", timestamp, nonce, []byte(`{"code":"FAIL"}`), signature)
+	if err != ErrBadSignature {
+		t.Fatalf("verifyResponse with tampered body = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestVerifyResponseUnknownSerial(t *testing.T) {
+	c, _ := newTestClientWithCert(t)
+
+	err := c.verifyResponse("unknown-serial", "1700000000", "testnonce", []byte("{}"), "sig")
+	if err != ErrNoPlatformCert {
+		t.Fatalf("verifyResponse with unknown serial = %v, want ErrNoPlatformCert", err)
+	}
+}
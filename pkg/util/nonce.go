@@ -0,0 +1,14 @@
+package util
+
+import mathrand "math/rand"
+
+const nonceAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// NonceStr 生成长度为n的随机字符串，供v2(XML/MD5)和v3(JSON/REST)协议共用的签名/客户端参数使用。
+func NonceStr(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = nonceAlphabet[mathrand.Intn(len(nonceAlphabet))]
+	}
+	return string(b)
+}